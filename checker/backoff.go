@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// backoffPolicy mirrors the handful of knobs callers of
+// github.com/cenkalti/backoff usually tune; it's small enough to keep
+// in-tree rather than pull in the dependency.
+type backoffPolicy struct {
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	maxRetries      int
+}
+
+func defaultBackoffPolicy(maxRetries int) backoffPolicy {
+	return backoffPolicy{
+		initialInterval: 500 * time.Millisecond,
+		maxInterval:     30 * time.Second,
+		maxRetries:      maxRetries,
+	}
+}
+
+// retryableCodes are the gRPC statuses worth retrying: they indicate a
+// transient quota or availability problem rather than a real error with
+// the request itself.
+var retryableCodes = map[codes.Code]bool{
+	codes.ResourceExhausted: true,
+	codes.Unavailable:       true,
+	codes.DeadlineExceeded:  true,
+}
+
+// isRetryable reports whether err looks transient enough to retry.
+func isRetryable(err error) bool {
+	return retryableCodes[status.Code(err)]
+}
+
+// withRetry calls fn, retrying with exponential backoff and jitter while
+// err is retryable, up to policy.maxRetries retries (so at most
+// policy.maxRetries+1 calls in all). It returns as soon
+// as fn succeeds or returns a non-retryable (terminal) error.
+func withRetry(ctx context.Context, policy backoffPolicy, fn func() error) error {
+	interval := policy.initialInterval
+
+	var err error
+	for attempt := 0; attempt <= policy.maxRetries; attempt++ {
+		err = fn()
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		if attempt == policy.maxRetries {
+			break
+		}
+
+		sleep := jitter(interval)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+
+		interval = time.Duration(math.Min(float64(interval)*2, float64(policy.maxInterval)))
+	}
+
+	return err
+}
+
+// jitter returns a random duration in [interval/2, interval] to avoid
+// every check synchronizing its retries together.
+func jitter(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	half := interval / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,140 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{status.Error(codes.ResourceExhausted, "quota"), true},
+		{status.Error(codes.Unavailable, "down"), true},
+		{status.Error(codes.DeadlineExceeded, "timeout"), true},
+		{status.Error(codes.PermissionDenied, "no"), false},
+		{errors.New("plain error"), false},
+		{nil, false},
+	}
+
+	for _, c := range cases {
+		if got := isRetryable(c.err); got != c.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestWithRetrySucceedsWithoutRetrying(t *testing.T) {
+	policy := backoffPolicy{initialInterval: time.Millisecond, maxInterval: time.Millisecond, maxRetries: 3}
+
+	calls := 0
+	err := withRetry(context.Background(), policy, func() error {
+		calls++
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("withRetry returned error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("withRetry called fn %d times, want 1", calls)
+	}
+}
+
+func TestWithRetryRetriesTransientErrors(t *testing.T) {
+	policy := backoffPolicy{initialInterval: time.Millisecond, maxInterval: time.Millisecond, maxRetries: 3}
+
+	calls := 0
+	err := withRetry(context.Background(), policy, func() error {
+		calls++
+		if calls < 3 {
+			return status.Error(codes.Unavailable, "down")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("withRetry returned error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("withRetry called fn %d times, want 3", calls)
+	}
+}
+
+func TestWithRetryStopsOnTerminalError(t *testing.T) {
+	policy := backoffPolicy{initialInterval: time.Millisecond, maxInterval: time.Millisecond, maxRetries: 3}
+
+	calls := 0
+	terminal := status.Error(codes.PermissionDenied, "nope")
+	err := withRetry(context.Background(), policy, func() error {
+		calls++
+		return terminal
+	})
+
+	if err != terminal {
+		t.Fatalf("withRetry returned %v, want %v", err, terminal)
+	}
+	if calls != 1 {
+		t.Errorf("withRetry called fn %d times, want 1 (no retries for a terminal error)", calls)
+	}
+}
+
+func TestWithRetryGivesUpAfterMaxRetries(t *testing.T) {
+	policy := backoffPolicy{initialInterval: time.Millisecond, maxInterval: time.Millisecond, maxRetries: 2}
+
+	calls := 0
+	transient := status.Error(codes.Unavailable, "down")
+	err := withRetry(context.Background(), policy, func() error {
+		calls++
+		return transient
+	})
+
+	if err != transient {
+		t.Fatalf("withRetry returned %v, want %v", err, transient)
+	}
+	if calls != policy.maxRetries+1 {
+		t.Errorf("withRetry called fn %d times, want %d", calls, policy.maxRetries+1)
+	}
+}
+
+func TestWithRetryRespectsContextCancellation(t *testing.T) {
+	policy := backoffPolicy{initialInterval: time.Hour, maxInterval: time.Hour, maxRetries: 3}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := withRetry(ctx, policy, func() error {
+		calls++
+		return status.Error(codes.Unavailable, "down")
+	})
+
+	if err != context.Canceled {
+		t.Fatalf("withRetry returned %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("withRetry called fn %d times, want 1", calls)
+	}
+}
+
+func TestJitterStaysInRange(t *testing.T) {
+	interval := 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		got := jitter(interval)
+		if got < interval/2 || got > interval {
+			t.Fatalf("jitter(%v) = %v, want within [%v, %v]", interval, got, interval/2, interval)
+		}
+	}
+}
+
+func TestJitterZero(t *testing.T) {
+	if got := jitter(0); got != 0 {
+		t.Errorf("jitter(0) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,142 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"github.com/golang/protobuf/ptypes/duration"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	googlepb "github.com/golang/protobuf/ptypes/timestamp"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+)
+
+const checkIntervalInMinutes = 5
+
+// Checker fetches Stackdriver time series for a batch of Specs,
+// spreading the requests out under a requests-per-second cap and
+// retrying transient failures.
+type Checker struct {
+	client     *monitoring.MetricClient
+	limiter    *tokenBucket
+	maxRetries int
+}
+
+// NewChecker builds a Checker that issues at most requestsPerSecond
+// ListTimeSeries calls per second, retrying transient errors up to
+// maxRetries times.
+func NewChecker(client *monitoring.MetricClient, requestsPerSecond float64, maxRetries int) *Checker {
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = 14
+	}
+
+	return &Checker{
+		client:     client,
+		limiter:    newTokenBucket(requestsPerSecond),
+		maxRetries: maxRetries,
+	}
+}
+
+// FetchAll resolves every Spec concurrently and returns one Result per
+// Spec, in the same order as specs.
+func (c *Checker) FetchAll(ctx context.Context, specs []Spec) []Result {
+	results := make([]Result, len(specs))
+
+	var wg sync.WaitGroup
+	for i, spec := range specs {
+		wg.Add(1)
+		go func(i int, spec Spec) {
+			defer wg.Done()
+			series, err := c.fetchOne(ctx, spec)
+			results[i] = Result{Spec: spec, Series: series, Err: err}
+		}(i, spec)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (c *Checker) fetchOne(ctx context.Context, spec Spec) ([]*monitoringpb.TimeSeries, error) {
+	var series []*monitoringpb.TimeSeries
+
+	policy := defaultBackoffPolicy(c.maxRetries)
+	err := withRetry(ctx, policy, func() error {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		series = nil
+		it := c.client.ListTimeSeries(ctx, buildRequest(spec))
+		for {
+			resp, err := it.Next()
+			if err == iterator.Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			series = append(series, resp)
+		}
+	})
+
+	if err != nil {
+		return nil, classifyError(spec, err)
+	}
+
+	return series, nil
+}
+
+func buildRequest(spec Spec) *monitoringpb.ListTimeSeriesRequest {
+	intervalStartTime := &googlepb.Timestamp{Seconds: time.Now().Add(-time.Minute * checkIntervalInMinutes).Unix()}
+	intervalEndTime := &googlepb.Timestamp{Seconds: time.Now().Unix()}
+
+	return &monitoringpb.ListTimeSeriesRequest{
+		Name:   fmt.Sprintf("projects/%s", spec.Project),
+		Filter: spec.Filter,
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: intervalStartTime,
+			EndTime:   intervalEndTime,
+		},
+		Aggregation: &monitoringpb.Aggregation{
+			AlignmentPeriod:    &duration.Duration{Seconds: 60 * checkIntervalInMinutes},
+			PerSeriesAligner:   monitoringpb.Aggregation_ALIGN_MEAN,
+			CrossSeriesReducer: monitoringpb.Aggregation_REDUCE_MEAN,
+		},
+	}
+}
+
+// TerminalError wraps an error that a retry loop should never have
+// attempted to fix, tagged with a short Reason an operator can use to
+// tell an auth/quota problem from a failing check at a glance.
+type TerminalError struct {
+	Reason string
+	Err    error
+}
+
+func (e *TerminalError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *TerminalError) Unwrap() error {
+	return e.Err
+}
+
+// classifyError tags well-known terminal failures so callers can
+// surface a distinct UNKNOWN message instead of a generic one, with
+// enough of spec's context to tell which check hit the problem.
+func classifyError(spec Spec, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "SERVICE_DISABLED"):
+		return &TerminalError{Reason: "SERVICE_DISABLED", Err: fmt.Errorf("project %s: %w", spec.Project, err)}
+	case status.Code(err) == codes.PermissionDenied:
+		return &TerminalError{Reason: "PERMISSION_DENIED", Err: fmt.Errorf("project %s: %w", spec.Project, err)}
+	default:
+		return err
+	}
+}
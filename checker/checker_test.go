@@ -0,0 +1,65 @@
+package checker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyErrorServiceDisabled(t *testing.T) {
+	spec := Spec{Name: "my-check", Project: "my-project"}
+	orig := status.Error(codes.PermissionDenied, "Stackdriver Monitoring API has not been used... SERVICE_DISABLED")
+
+	err := classifyError(spec, orig)
+
+	var terminal *TerminalError
+	if !errors.As(err, &terminal) {
+		t.Fatalf("classifyError = %v, want a *TerminalError", err)
+	}
+	if terminal.Reason != "SERVICE_DISABLED" {
+		t.Errorf("terminal.Reason = %q, want %q", terminal.Reason, "SERVICE_DISABLED")
+	}
+	if !strings.Contains(terminal.Error(), spec.Project) {
+		t.Errorf("terminal.Error() = %q, want it to mention project %q", terminal.Error(), spec.Project)
+	}
+	if !errors.Is(err, orig) {
+		t.Errorf("classifyError result does not unwrap to the original error")
+	}
+}
+
+func TestClassifyErrorPermissionDenied(t *testing.T) {
+	spec := Spec{Name: "my-check", Project: "my-project"}
+	orig := status.Error(codes.PermissionDenied, "caller does not have permission")
+
+	err := classifyError(spec, orig)
+
+	var terminal *TerminalError
+	if !errors.As(err, &terminal) {
+		t.Fatalf("classifyError = %v, want a *TerminalError", err)
+	}
+	if terminal.Reason != "PERMISSION_DENIED" {
+		t.Errorf("terminal.Reason = %q, want %q", terminal.Reason, "PERMISSION_DENIED")
+	}
+	if !strings.Contains(terminal.Error(), spec.Project) {
+		t.Errorf("terminal.Error() = %q, want it to mention project %q", terminal.Error(), spec.Project)
+	}
+}
+
+func TestClassifyErrorPassthrough(t *testing.T) {
+	spec := Spec{Name: "my-check", Project: "my-project"}
+	orig := status.Error(codes.Unavailable, "temporarily unavailable")
+
+	err := classifyError(spec, orig)
+
+	if err != orig {
+		t.Errorf("classifyError = %v, want the original error passed through unchanged", err)
+	}
+
+	var terminal *TerminalError
+	if errors.As(err, &terminal) {
+		t.Errorf("classifyError should not tag %v as a TerminalError", orig)
+	}
+}
@@ -0,0 +1,68 @@
+package checker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a minimal requests-per-second limiter: it refills at a
+// fixed rate and blocks Wait callers until a token is available.
+// Stackdriver's default quota is around 14 ListTimeSeries calls per
+// second per project, so callers are expected to size rate accordingly.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	rate       float64
+	lastRefill time.Time
+}
+
+func newTokenBucket(ratePerSecond float64) *tokenBucket {
+	return &tokenBucket{
+		tokens:     ratePerSecond,
+		maxTokens:  ratePerSecond,
+		rate:       ratePerSecond,
+		lastRefill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		wait := b.take()
+		if wait <= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// take refills the bucket based on elapsed time and either consumes a
+// token (returning 0) or reports how long the caller must wait.
+func (b *tokenBucket) take() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	missing := 1 - b.tokens
+	return time.Duration(missing / b.rate * float64(time.Second))
+}
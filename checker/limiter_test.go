@@ -0,0 +1,58 @@
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsBurstUpToRate(t *testing.T) {
+	b := newTokenBucket(5)
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("first %d tokens took %v, want near-instant", 5, elapsed)
+	}
+}
+
+func TestTokenBucketThrottlesBeyondRate(t *testing.T) {
+	b := newTokenBucket(10)
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+
+	start := time.Now()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("11th token at 10/s was granted after %v, want it to wait", elapsed)
+	}
+}
+
+func TestTokenBucketRespectsContextCancellation(t *testing.T) {
+	b := newTokenBucket(1)
+
+	ctx := context.Background()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := b.Wait(cancelCtx); err != context.Canceled {
+		t.Errorf("Wait on a cancelled context returned %v, want context.Canceled", err)
+	}
+}
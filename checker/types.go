@@ -0,0 +1,40 @@
+// Package checker is the library half of the plugin: given a set of
+// Stackdriver time series to check, it fetches them concurrently under
+// a requests-per-second cap, retrying transient failures with backoff,
+// and hands back either the raw series or a classified error.
+package checker
+
+import (
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+)
+
+// Spec describes a single Stackdriver time series to evaluate.
+type Spec struct {
+	Name       string
+	Project    string
+	Filter     string
+	Summary    string
+	Percentile float64
+
+	// Comparison is one of gt, lt, eq, ne, ge, le, or range (the
+	// default); "range" parses Warning/CriticalThreshold as standard
+	// Nagios range syntax, the others compare a plain number.
+	Comparison string
+
+	// Reducer picks how a series' points collapse to the single value
+	// Comparison is checked against: "" or "last", "rate", "delta",
+	// "min", "max", "mean", or "stddev".
+	Reducer string
+
+	WarningThreshold  string
+	CriticalThreshold string
+}
+
+// Result is what a single Spec resolved to: either the time series
+// returned by Stackdriver, or an error describing why it couldn't be
+// fetched (already retried as appropriate).
+type Result struct {
+	Spec   Spec
+	Series []*monitoringpb.TimeSeries
+	Err    error
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/itaym2/icinga-stackdriver-plugin/checker"
+)
+
+// checkConfig is the shape of the -config file: a named project default
+// plus a list of checks to run against it.
+type checkConfig struct {
+	Project string         `json:"project"`
+	Checks  []checker.Spec `json:"checks"`
+}
+
+// loadConfigFile reads a JSON config file and returns the checker.Specs
+// it describes, filling in the top-level project for any check that
+// didn't set its own.
+func loadConfigFile(path string) ([]checker.Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var cfg checkConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+
+	if len(cfg.Checks) == 0 {
+		return nil, fmt.Errorf("config file %s does not define any checks", path)
+	}
+
+	for i := range cfg.Checks {
+		if cfg.Checks[i].Project == "" {
+			cfg.Checks[i].Project = cfg.Project
+		}
+		if cfg.Checks[i].Name == "" {
+			cfg.Checks[i].Name = cfg.Checks[i].Filter
+		}
+	}
+
+	return cfg.Checks, nil
+}
+
+// filterFlags collects repeated -filter flags into a slice.
+type filterFlags []string
+
+func (f *filterFlags) String() string {
+	return fmt.Sprint([]string(*f))
+}
+
+func (f *filterFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
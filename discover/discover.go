@@ -0,0 +1,213 @@
+// Package discover implements the "discover" subcommand, which enumerates
+// the (resource type, metric type) pairs available in a Stackdriver
+// project so operators don't have to hand-write -filter strings.
+package discover
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3"
+	"google.golang.org/api/iterator"
+	metricpb "google.golang.org/genproto/googleapis/api/metric"
+	monitoredrespb "google.golang.org/genproto/googleapis/api/monitoredres"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+
+	"github.com/itaym2/icinga-stackdriver-plugin/gcpauth"
+)
+
+// pair is one discovered (metric type, resource type) combination.
+type pair struct {
+	metricType   string
+	resourceType string
+}
+
+// Run parses the discover subcommand's own flags out of args and prints
+// the metric/resource pairs it finds for the target project.
+func Run(args []string) {
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+
+	project := fs.String("project", "", "name of the google project to enumerate")
+	var includePrefixes prefixFlags
+	var excludePrefixes prefixFlags
+	fs.Var(&includePrefixes, "include", "only enumerate metric types starting with this prefix; may be repeated")
+	fs.Var(&excludePrefixes, "exclude", "skip metric types starting with this prefix; may be repeated")
+	emitApply := fs.Bool("emitApply", false, "emit an Icinga2 \"apply Service for\" config block instead of plain filter strings")
+	checkCommand := fs.String("checkCommand", "icinga-stackdriver-plugin-check", "Icinga2 check_command name to reference in the emitted apply block")
+	auth := gcpauth.RegisterFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("Failed to parse discover flags: %v", err)
+	}
+
+	if *project == "" {
+		log.Fatalf("Missing project param")
+	}
+
+	ctx := context.Background()
+
+	clientOpts, err := gcpauth.ClientOptions(ctx, auth)
+	if err != nil {
+		log.Fatalf("Failed to resolve credentials: %v", err)
+	}
+
+	client, err := monitoring.NewMetricClient(ctx, clientOpts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	resourceDescriptors, err := listMonitoredResourceDescriptors(ctx, client, *project)
+	if err != nil {
+		log.Fatalf("Failed to list monitored resource descriptors: %v", err)
+	}
+
+	metricDescriptors, err := listMetricDescriptors(ctx, client, *project, includePrefixes, excludePrefixes)
+	if err != nil {
+		log.Fatalf("Failed to list metric descriptors: %v", err)
+	}
+
+	pairs := expandPairs(metricDescriptors, resourceDescriptors)
+
+	if *emitApply {
+		fmt.Print(renderApplyBlock(pairs, *checkCommand))
+		return
+	}
+
+	for _, p := range pairs {
+		fmt.Println(renderFilter(p))
+	}
+}
+
+func listMetricDescriptors(ctx context.Context, client *monitoring.MetricClient, project string, include, exclude prefixFlags) ([]*metricpb.MetricDescriptor, error) {
+	req := &monitoringpb.ListMetricDescriptorsRequest{
+		Name:   fmt.Sprintf("projects/%s", project),
+		Filter: buildPrefixFilter(include),
+	}
+
+	var descriptors []*metricpb.MetricDescriptor
+	it := client.ListMetricDescriptors(ctx, req)
+	for {
+		d, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if excluded(d.GetType(), exclude) {
+			continue
+		}
+		descriptors = append(descriptors, d)
+	}
+
+	return descriptors, nil
+}
+
+func listMonitoredResourceDescriptors(ctx context.Context, client *monitoring.MetricClient, project string) ([]*monitoredrespb.MonitoredResourceDescriptor, error) {
+	req := &monitoringpb.ListMonitoredResourceDescriptorsRequest{
+		Name: fmt.Sprintf("projects/%s", project),
+	}
+
+	var descriptors []*monitoredrespb.MonitoredResourceDescriptor
+	it := client.ListMonitoredResourceDescriptors(ctx, req)
+	for {
+		d, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, d)
+	}
+
+	return descriptors, nil
+}
+
+// expandPairs cross-references each metric descriptor's declared
+// MonitoredResourceTypes against the resource descriptors actually
+// available in the project, falling back to a resourceless pair when a
+// metric descriptor doesn't declare any (some custom metrics don't).
+func expandPairs(metrics []*metricpb.MetricDescriptor, resources []*monitoredrespb.MonitoredResourceDescriptor) []pair {
+	known := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		known[r.GetType()] = true
+	}
+
+	var pairs []pair
+	for _, m := range metrics {
+		resourceTypes := m.GetMonitoredResourceTypes()
+		if len(resourceTypes) == 0 {
+			pairs = append(pairs, pair{metricType: m.GetType()})
+			continue
+		}
+		for _, rt := range resourceTypes {
+			if !known[rt] {
+				continue
+			}
+			pairs = append(pairs, pair{metricType: m.GetType(), resourceType: rt})
+		}
+	}
+
+	return pairs
+}
+
+func renderFilter(p pair) string {
+	if p.resourceType == "" {
+		return fmt.Sprintf(`metric.type="%s"`, p.metricType)
+	}
+	return fmt.Sprintf(`metric.type="%s" AND resource.type="%s"`, p.metricType, p.resourceType)
+}
+
+func renderApplyBlock(pairs []pair, checkCommand string) string {
+	var sb strings.Builder
+	for _, p := range pairs {
+		name := p.metricType
+		if p.resourceType != "" {
+			name = fmt.Sprintf("%s on %s", p.metricType, p.resourceType)
+		}
+
+		fmt.Fprintf(&sb, "apply Service \"%s\" {\n", name)
+		fmt.Fprintf(&sb, "  check_command = \"%s\"\n", checkCommand)
+		fmt.Fprintf(&sb, "  vars.stackdriver_filter = %q\n", renderFilter(p))
+		sb.WriteString("  assign where host.vars.gcp_project\n")
+		sb.WriteString("}\n\n")
+	}
+	return sb.String()
+}
+
+func buildPrefixFilter(prefixes prefixFlags) string {
+	if len(prefixes) == 0 {
+		return ""
+	}
+
+	clauses := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		clauses[i] = fmt.Sprintf(`metric.type = starts_with("%s")`, p)
+	}
+	return strings.Join(clauses, " OR ")
+}
+
+func excluded(metricType string, prefixes prefixFlags) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(metricType, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixFlags collects repeated -include/-exclude flags into a slice.
+type prefixFlags []string
+
+func (f *prefixFlags) String() string {
+	return fmt.Sprint([]string(*f))
+}
+
+func (f *prefixFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,110 @@
+package discover
+
+import (
+	"testing"
+
+	metricpb "google.golang.org/genproto/googleapis/api/metric"
+	monitoredrespb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+func TestExpandPairs(t *testing.T) {
+	metrics := []*metricpb.MetricDescriptor{
+		{Type: "compute.googleapis.com/instance/cpu/usage_time", MonitoredResourceTypes: []string{"gce_instance"}},
+		{Type: "custom.googleapis.com/app/requests", MonitoredResourceTypes: []string{"gce_instance", "k8s_pod"}},
+		{Type: "custom.googleapis.com/app/no_resources"},
+	}
+	resources := []*monitoredrespb.MonitoredResourceDescriptor{
+		{Type: "gce_instance"},
+	}
+
+	got := expandPairs(metrics, resources)
+	want := []pair{
+		{metricType: "compute.googleapis.com/instance/cpu/usage_time", resourceType: "gce_instance"},
+		{metricType: "custom.googleapis.com/app/requests", resourceType: "gce_instance"},
+		{metricType: "custom.googleapis.com/app/no_resources"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expandPairs = %+v, want %+v", got, want)
+	}
+	for i, p := range got {
+		if p != want[i] {
+			t.Errorf("expandPairs[%d] = %+v, want %+v", i, p, want[i])
+		}
+	}
+}
+
+func TestRenderFilter(t *testing.T) {
+	cases := []struct {
+		name string
+		p    pair
+		want string
+	}{
+		{
+			"with resource type",
+			pair{metricType: "compute.googleapis.com/instance/cpu/usage_time", resourceType: "gce_instance"},
+			`metric.type="compute.googleapis.com/instance/cpu/usage_time" AND resource.type="gce_instance"`,
+		},
+		{
+			"without resource type",
+			pair{metricType: "custom.googleapis.com/app/no_resources"},
+			`metric.type="custom.googleapis.com/app/no_resources"`,
+		},
+	}
+
+	for _, c := range cases {
+		if got := renderFilter(c.p); got != c.want {
+			t.Errorf("%s: renderFilter = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBuildPrefixFilter(t *testing.T) {
+	if got := buildPrefixFilter(nil); got != "" {
+		t.Errorf("buildPrefixFilter(nil) = %q, want empty string", got)
+	}
+
+	got := buildPrefixFilter(prefixFlags{"compute.googleapis.com", "custom.googleapis.com"})
+	want := `metric.type = starts_with("compute.googleapis.com") OR metric.type = starts_with("custom.googleapis.com")`
+	if got != want {
+		t.Errorf("buildPrefixFilter = %q, want %q", got, want)
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	prefixes := prefixFlags{"custom.googleapis.com/internal"}
+
+	if !excluded("custom.googleapis.com/internal/foo", prefixes) {
+		t.Error("excluded should match a metric type starting with an excluded prefix")
+	}
+	if excluded("custom.googleapis.com/app/foo", prefixes) {
+		t.Error("excluded should not match a metric type that doesn't start with an excluded prefix")
+	}
+	if excluded("custom.googleapis.com/internal/foo", nil) {
+		t.Error("excluded with no prefixes should never match")
+	}
+}
+
+func TestRenderApplyBlock(t *testing.T) {
+	pairs := []pair{
+		{metricType: "compute.googleapis.com/instance/cpu/usage_time", resourceType: "gce_instance"},
+		{metricType: "custom.googleapis.com/app/no_resources"},
+	}
+
+	got := renderApplyBlock(pairs, "icinga-stackdriver-plugin-check")
+
+	want := "apply Service \"compute.googleapis.com/instance/cpu/usage_time on gce_instance\" {\n" +
+		"  check_command = \"icinga-stackdriver-plugin-check\"\n" +
+		`  vars.stackdriver_filter = "metric.type=\"compute.googleapis.com/instance/cpu/usage_time\" AND resource.type=\"gce_instance\""` + "\n" +
+		"  assign where host.vars.gcp_project\n" +
+		"}\n\n" +
+		"apply Service \"custom.googleapis.com/app/no_resources\" {\n" +
+		"  check_command = \"icinga-stackdriver-plugin-check\"\n" +
+		`  vars.stackdriver_filter = "metric.type=\"custom.googleapis.com/app/no_resources\""` + "\n" +
+		"  assign where host.vars.gcp_project\n" +
+		"}\n\n"
+
+	if got != want {
+		t.Errorf("renderApplyBlock = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+// Package gcpauth builds the option.ClientOptions used to authenticate
+// to Google Cloud APIs, so a single Icinga host can run checks against
+// many GCP projects under distinct service accounts without
+// shell-swapping GOOGLE_APPLICATION_CREDENTIALS.
+package gcpauth
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+// credentialsJSONEnvVar is the fallback for -credentialsJSON, handy when
+// the credentials shouldn't be passed as a command-line argument.
+const credentialsJSONEnvVar = "STACKDRIVER_CREDENTIALS_JSON"
+
+const defaultScope = "https://www.googleapis.com/auth/monitoring.read"
+
+// Options configures how the plugin authenticates to Google Cloud APIs.
+// The zero value authenticates with ambient Application Default
+// Credentials, matching the plugin's original behavior.
+type Options struct {
+	CredentialsFile           string
+	CredentialsJSON           string
+	ImpersonateServiceAccount string
+	QuotaProject              string
+	Scopes                    string // comma-separated
+	UseMetadataServer         bool
+}
+
+// RegisterFlags adds the auth flags to fs and returns the Options they
+// populate once fs.Parse has run.
+func RegisterFlags(fs *flag.FlagSet) *Options {
+	opts := &Options{}
+
+	fs.StringVar(&opts.CredentialsFile, "credentialsFile", "", "path to a service account JSON key file to authenticate with, instead of ambient Application Default Credentials")
+	fs.StringVar(&opts.CredentialsJSON, "credentialsJSON", "", "service account JSON key, inline, as an alternative to -credentialsFile (falls back to the "+credentialsJSONEnvVar+" env var)")
+	fs.StringVar(&opts.ImpersonateServiceAccount, "impersonateServiceAccount", "", "email of a service account to impersonate, using the caller's own credentials to mint short-lived tokens for it")
+	fs.StringVar(&opts.QuotaProject, "quotaProject", "", "project to bill API quota to, if different from the project being checked")
+	fs.StringVar(&opts.Scopes, "scopes", defaultScope, "comma-separated OAuth scopes to request; applied only with -credentialsFile, -credentialsJSON, -impersonateServiceAccount or -useMetadataServer, not to ambient Application Default Credentials")
+	fs.BoolVar(&opts.UseMetadataServer, "useMetadataServer", false, "fetch credentials from the GCE metadata server instead of ambient Application Default Credentials")
+
+	return opts
+}
+
+// ClientOptions resolves opts into the option.ClientOptions
+// monitoring.NewMetricClient should be constructed with. With no flags
+// set it returns no options at all, so the client falls back to its
+// normal ambient Application Default Credentials behavior.
+func ClientOptions(ctx context.Context, opts *Options) ([]option.ClientOption, error) {
+	scopes := opts.Scopes
+	if scopes == "" {
+		scopes = defaultScope
+	}
+	scopeList := strings.Split(scopes, ",")
+
+	credentialsJSON := opts.CredentialsJSON
+	if credentialsJSON == "" {
+		credentialsJSON = os.Getenv(credentialsJSONEnvVar)
+	}
+
+	var clientOpts []option.ClientOption
+
+	switch {
+	case opts.ImpersonateServiceAccount != "":
+		ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+			TargetPrincipal: opts.ImpersonateServiceAccount,
+			Scopes:          scopeList,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to impersonate %s: %v", opts.ImpersonateServiceAccount, err)
+		}
+		clientOpts = append(clientOpts, option.WithTokenSource(ts))
+	case opts.CredentialsFile != "":
+		clientOpts = append(clientOpts, option.WithCredentialsFile(opts.CredentialsFile))
+	case credentialsJSON != "":
+		clientOpts = append(clientOpts, option.WithCredentialsJSON([]byte(credentialsJSON)))
+	case opts.UseMetadataServer:
+		clientOpts = append(clientOpts, option.WithTokenSource(google.ComputeTokenSource("", scopeList...)))
+	}
+
+	if len(clientOpts) > 0 {
+		clientOpts = append(clientOpts, option.WithScopes(scopeList...))
+	}
+
+	if opts.QuotaProject != "" {
+		clientOpts = append(clientOpts, option.WithQuotaProject(opts.QuotaProject))
+	}
+
+	return clientOpts, nil
+}
@@ -5,29 +5,31 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"time"
+	"os"
+	"strings"
 
-	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/olorin/nagiosplugin"
-	"google.golang.org/api/iterator"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3"
-	googlepb "github.com/golang/protobuf/ptypes/timestamp"
-	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
-)
 
-const (
-	checkIntervalInMinutes = 5
+	"github.com/itaym2/icinga-stackdriver-plugin/checker"
+	"github.com/itaym2/icinga-stackdriver-plugin/discover"
+	"github.com/itaym2/icinga-stackdriver-plugin/gcpauth"
 )
 
 type options struct {
-	filter            string
-	project           string
-	criticalThreshold int
-	warningThreshold  int
+	checks            []checker.Spec
+	requestsPerSecond float64
+	maxRetries        int
+	auth              *gcpauth.Options
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "discover" {
+		discover.Run(os.Args[2:])
+		return
+	}
+
 	options := getOptions()
 
 	check := nagiosplugin.NewCheck()
@@ -35,43 +37,52 @@ func main() {
 	check.AddResult(nagiosplugin.OK, "Check succeeded")
 
 	ctx := context.Background()
-	client, err := monitoring.NewMetricClient(ctx)
 
+	clientOpts, err := gcpauth.ClientOptions(ctx, options.auth)
 	if err != nil {
 		check.AddResult(nagiosplugin.UNKNOWN, "Failed to perform check")
-		log.Fatalf("Failed to create client: %v", err)
+		log.Fatalf("Failed to resolve credentials: %v", err)
 	}
 
-	intervalStartTime := &googlepb.Timestamp{Seconds: time.Now().Add(-time.Minute * checkIntervalInMinutes).Unix()}
-	intervalEndTime := &googlepb.Timestamp{Seconds: time.Now().Unix()}
-
-	request := &monitoringpb.ListTimeSeriesRequest{
-		Name:   fmt.Sprintf("projects/%s", options.project),
-		Filter: options.filter,
-		Interval: &monitoringpb.TimeInterval{
-			StartTime: intervalStartTime,
-			EndTime:   intervalEndTime,
-		},
-		Aggregation: &monitoringpb.Aggregation{
-			AlignmentPeriod:    &duration.Duration{Seconds: 60 * checkIntervalInMinutes},
-			PerSeriesAligner:   monitoringpb.Aggregation_ALIGN_MEAN,
-			CrossSeriesReducer: monitoringpb.Aggregation_REDUCE_MEAN,
-		},
+	client, err := monitoring.NewMetricClient(ctx, clientOpts...)
+
+	if err != nil {
+		check.AddResult(nagiosplugin.UNKNOWN, "Failed to perform check")
+		log.Fatalf("Failed to create client: %v", err)
 	}
 
-	it := client.ListTimeSeries(ctx, request)
-	handleResult(it, options.criticalThreshold, options.warningThreshold, check)
+	c := checker.NewChecker(client, options.requestsPerSecond, options.maxRetries)
+	for _, result := range c.FetchAll(ctx, options.checks) {
+		handleResult(result, check)
+	}
 }
 
 func getOptions() *options {
-	filter := flag.String("filter", "", "time series filter")
+	var filters filterFlags
+	flag.Var(&filters, "filter", "time series filter; may be repeated to check several series in one run")
+	config := flag.String("config", "", "path to a JSON file describing multiple named checks, as an alternative to -filter")
 	project := flag.String("project", "", "name of the google pubsub project containing the monitored resource")
-	criticalThreshold := flag.Int("criticalThreshold", -1, "critical alert when result in greater than or equal to this threashold")
-	warningThreshold := flag.Int("warningThreshold", -1, "warning alert when result in greater than or equal to this threashold")
+	summary := flag.String("summary", "mean", "how to summarize a DistributionValue: mean, count, sum, or percentile")
+	percentile := flag.Float64("percentile", 99, "percentile (0-100) to report when -summary=percentile")
+	comparison := flag.String("comparison", "range", "how to compare the reduced value against the thresholds: gt, lt, eq, ne, ge, le, or range")
+	reducer := flag.String("reducer", "last", "how to collapse a series' points to one value: last, rate, delta, min, max, mean, or stddev")
+	criticalThreshold := flag.String("criticalThreshold", "", "critical alert threshold; a plain number, or Nagios range syntax when -comparison=range")
+	warningThreshold := flag.String("warningThreshold", "", "warning alert threshold; a plain number, or Nagios range syntax when -comparison=range")
+	requestsPerSecond := flag.Float64("requestsPerSecond", 14, "maximum ListTimeSeries requests per second to issue against the project's quota")
+	maxRetries := flag.Int("maxRetries", 5, "number of times to retry a check after a transient Stackdriver error before giving up")
+	auth := gcpauth.RegisterFlags(flag.CommandLine)
 
 	flag.Parse()
 
-	if *filter == "" {
+	if *config != "" {
+		checks, err := loadConfigFile(*config)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		return &options{checks: checks, requestsPerSecond: *requestsPerSecond, maxRetries: *maxRetries, auth: auth}
+	}
+
+	if len(filters) == 0 {
 		log.Fatalf("Missing filter param")
 	}
 
@@ -79,46 +90,112 @@ func getOptions() *options {
 		log.Fatalf("Missing project param")
 	}
 
-	if *warningThreshold == -1 && *criticalThreshold == -1 {
+	if *warningThreshold == "" && *criticalThreshold == "" {
 		log.Fatalf("you must provide either criticalThreshold param or warningThreshold param")
 	}
 
-	return &options{
-		filter:            *filter,
-		project:           *project,
-		criticalThreshold: *criticalThreshold,
-		warningThreshold:  *warningThreshold,
+	checks := make([]checker.Spec, len(filters))
+	for i, filter := range filters {
+		checks[i] = checker.Spec{
+			Name:              filter,
+			Project:           *project,
+			Filter:            filter,
+			Summary:           *summary,
+			Percentile:        *percentile,
+			Comparison:        *comparison,
+			Reducer:           *reducer,
+			WarningThreshold:  *warningThreshold,
+			CriticalThreshold: *criticalThreshold,
+		}
 	}
+
+	return &options{checks: checks, requestsPerSecond: *requestsPerSecond, maxRetries: *maxRetries, auth: auth}
 }
 
-func handleResult(it *monitoring.TimeSeriesIterator, criticalThreshold int, warningThreshold int, check *nagiosplugin.Check) {
-	for {
-		resp, err := it.Next()
-		if err == iterator.Done {
-			check.AddResult(nagiosplugin.UNKNOWN, "Failed to perform check, No results returned from stackdriver API")
-			break
+func handleResult(result checker.Result, check *nagiosplugin.Check) {
+	spec := result.Spec
+
+	if result.Err != nil {
+		if terminal, ok := result.Err.(*checker.TerminalError); ok {
+			check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] %s: %v", spec.Name, terminal.Reason, terminal.Err))
+			return
 		}
+		check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] Failed to perform check: %v", spec.Name, result.Err))
+		return
+	}
 
+	if len(result.Series) == 0 {
+		check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] Failed to perform check, No results returned from stackdriver API", spec.Name))
+		return
+	}
+
+	for _, resp := range result.Series {
+		label := formatLabels(resp.GetMetric().GetLabels())
+
+		points := make([]timedValue, 0, len(resp.GetPoints()))
+		for _, point := range resp.GetPoints() {
+			value, err := extractValue(point.GetValue(), spec.Summary, spec.Percentile)
+			if err != nil {
+				check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] %v", spec.Name, err))
+				continue
+			}
+			points = append(points, timedValue{seconds: float64(point.GetInterval().GetEndTime().GetSeconds()), value: value})
+		}
+
+		if len(points) == 0 {
+			continue
+		}
+
+		// Stackdriver returns points newest-first; reducers expect
+		// oldest-to-newest.
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+
+		value, err := reduce(spec.Reducer, points)
 		if err != nil {
-			check.AddResult(nagiosplugin.UNKNOWN, "Failed to perform check")
-			log.Fatalf("Failed to fetch time series: %v", err)
+			check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] %v", spec.Name, err))
+			continue
 		}
 
-		if len(resp.Points) > 1 {
-			check.AddResult(nagiosplugin.UNKNOWN, "Failed to perform check, too many points in result")
-			log.Fatalf("Response contains more than 1 point, please refine filter and aggregation params so that only 1 point will return")
+		min, max := pointRange(points)
+		warn := thresholdFloat(spec.Comparison, spec.WarningThreshold)
+		crit := thresholdFloat(spec.Comparison, spec.CriticalThreshold)
+		if err := check.AddPerfDatum(spec.Name, "", value, min, max, warn, crit); err != nil {
+			check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] failed to add perfdata: %v", spec.Name, err))
 		}
 
-		value := resp.Points[0].GetValue().GetInt64Value()
+		message := fmt.Sprintf("[%s] %s = %v", spec.Name, label, value)
 
-		if value > int64(warningThreshold) {
-			check.AddResult(nagiosplugin.WARNING, "Result is greater than or equal to warning threshold")
+		critical, err := trips(spec.Comparison, spec.CriticalThreshold, value)
+		if err != nil {
+			check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] %v", spec.Name, err))
+			continue
+		}
+
+		warning, err := trips(spec.Comparison, spec.WarningThreshold, value)
+		if err != nil {
+			check.AddResult(nagiosplugin.UNKNOWN, fmt.Sprintf("[%s] %v", spec.Name, err))
+			continue
 		}
 
-		if value > int64(criticalThreshold) {
-			check.AddResult(nagiosplugin.CRITICAL, "Result is greater than or equal to critical threshold")
+		switch {
+		case critical:
+			check.AddResult(nagiosplugin.CRITICAL, message)
+		case warning:
+			check.AddResult(nagiosplugin.WARNING, message)
 		}
+	}
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "<no labels>"
+	}
 
-		break
+	parts := make([]string, 0, len(labels))
+	for k, v := range labels {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, v))
 	}
+	return strings.Join(parts, ",")
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	distributionpb "google.golang.org/genproto/googleapis/api/distribution"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+)
+
+// extractValue pulls a single float64 out of a TypedValue, resolving
+// distribution values down to the requested summary statistic.
+func extractValue(value *monitoringpb.TypedValue, summary string, percentile float64) (float64, error) {
+	switch v := value.GetValue().(type) {
+	case *monitoringpb.TypedValue_Int64Value:
+		return float64(v.Int64Value), nil
+	case *monitoringpb.TypedValue_DoubleValue:
+		return v.DoubleValue, nil
+	case *monitoringpb.TypedValue_BoolValue:
+		if v.BoolValue {
+			return 1, nil
+		}
+		return 0, nil
+	case *monitoringpb.TypedValue_DistributionValue:
+		return summarizeDistribution(v.DistributionValue, summary, percentile)
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// summarizeDistribution reduces a distribution to a single number
+// according to summary, which is one of "mean", "count", "sum", or
+// "percentile" (in which case percentile, 0-100, selects the bucket
+// boundary to report).
+func summarizeDistribution(d *distributionpb.Distribution, summary string, percentile float64) (float64, error) {
+	switch summary {
+	case "", "mean":
+		return d.GetMean(), nil
+	case "count":
+		return float64(d.GetCount()), nil
+	case "sum":
+		return d.GetMean() * float64(d.GetCount()), nil
+	case "percentile":
+		return distributionPercentile(d, percentile)
+	default:
+		return 0, fmt.Errorf("unknown distribution summary %q", summary)
+	}
+}
+
+// distributionPercentile walks the bucket counts in order and returns
+// the upper bound of the bucket in which the requested percentile
+// (0-100) falls.
+func distributionPercentile(d *distributionpb.Distribution, percentile float64) (float64, error) {
+	counts := d.GetBucketCounts()
+	total := d.GetCount()
+	if total == 0 || len(counts) == 0 {
+		return 0, fmt.Errorf("distribution has no buckets to compute a percentile from")
+	}
+
+	bounds, err := bucketUpperBounds(d.GetBucketOptions(), len(counts))
+	if err != nil {
+		return 0, err
+	}
+
+	target := percentile / 100 * float64(total)
+	var cumulative float64
+	for i, c := range counts {
+		cumulative += float64(c)
+		if cumulative >= target {
+			if i < len(bounds) {
+				return bounds[i], nil
+			}
+			return bounds[len(bounds)-1], nil
+		}
+	}
+
+	return bounds[len(bounds)-1], nil
+}
+
+// bucketUpperBounds expands a Distribution's BucketOptions into the
+// upper bound of each of its numBuckets buckets (the last, overflow,
+// bucket reuses the previous bound).
+func bucketUpperBounds(opts *distributionpb.Distribution_BucketOptions, numBuckets int) ([]float64, error) {
+	bounds := make([]float64, numBuckets)
+
+	switch o := opts.GetOptions().(type) {
+	case *distributionpb.Distribution_BucketOptions_ExplicitBuckets:
+		explicit := o.ExplicitBuckets.GetBounds()
+		for i := 0; i < numBuckets; i++ {
+			if i < len(explicit) {
+				bounds[i] = explicit[i]
+			} else if len(explicit) > 0 {
+				bounds[i] = explicit[len(explicit)-1]
+			}
+		}
+	case *distributionpb.Distribution_BucketOptions_LinearBuckets:
+		lin := o.LinearBuckets
+		for i := 0; i < numBuckets; i++ {
+			idx := i
+			if n := int(lin.GetNumFiniteBuckets()); n > 0 && idx > n {
+				idx = n
+			}
+			bounds[i] = lin.GetOffset() + lin.GetWidth()*float64(idx)
+		}
+	case *distributionpb.Distribution_BucketOptions_ExponentialBuckets:
+		exp := o.ExponentialBuckets
+		for i := 0; i < numBuckets; i++ {
+			idx := i
+			if n := int(exp.GetNumFiniteBuckets()); n > 0 && idx > n {
+				idx = n
+			}
+			bounds[i] = exp.GetScale() * math.Pow(exp.GetGrowthFactor(), float64(idx))
+		}
+	default:
+		return nil, fmt.Errorf("distribution has no bucket options set")
+	}
+
+	return bounds, nil
+}
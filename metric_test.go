@@ -0,0 +1,152 @@
+package main
+
+import (
+	"testing"
+
+	distributionpb "google.golang.org/genproto/googleapis/api/distribution"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+)
+
+func TestExtractValue(t *testing.T) {
+	cases := []struct {
+		name  string
+		value *monitoringpb.TypedValue
+		want  float64
+	}{
+		{"int64", &monitoringpb.TypedValue{Value: &monitoringpb.TypedValue_Int64Value{Int64Value: 42}}, 42},
+		{"double", &monitoringpb.TypedValue{Value: &monitoringpb.TypedValue_DoubleValue{DoubleValue: 1.5}}, 1.5},
+		{"bool true", &monitoringpb.TypedValue{Value: &monitoringpb.TypedValue_BoolValue{BoolValue: true}}, 1},
+		{"bool false", &monitoringpb.TypedValue{Value: &monitoringpb.TypedValue_BoolValue{BoolValue: false}}, 0},
+	}
+
+	for _, c := range cases {
+		got, err := extractValue(c.value, "mean", 99)
+		if err != nil {
+			t.Errorf("%s: extractValue returned error: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: extractValue = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestExtractValueUnsupportedType(t *testing.T) {
+	if _, err := extractValue(&monitoringpb.TypedValue{}, "mean", 99); err == nil {
+		t.Error("extractValue with no value set should return an error")
+	}
+}
+
+func distributionWithLinearBuckets(mean float64, count int64, counts []int64, offset, width float64) *distributionpb.Distribution {
+	return &distributionpb.Distribution{
+		Mean:  mean,
+		Count: count,
+		BucketOptions: &distributionpb.Distribution_BucketOptions{
+			Options: &distributionpb.Distribution_BucketOptions_LinearBuckets{
+				LinearBuckets: &distributionpb.Distribution_BucketOptions_Linear{
+					NumFiniteBuckets: int32(len(counts) - 1),
+					Width:            width,
+					Offset:           offset,
+				},
+			},
+		},
+		BucketCounts: counts,
+	}
+}
+
+func TestSummarizeDistribution(t *testing.T) {
+	d := distributionWithLinearBuckets(5, 10, []int64{0, 2, 8}, 0, 10)
+
+	if got, err := summarizeDistribution(d, "mean", 0); err != nil || got != 5 {
+		t.Errorf("summarizeDistribution mean = (%v, %v), want (5, nil)", got, err)
+	}
+	if got, err := summarizeDistribution(d, "count", 0); err != nil || got != 10 {
+		t.Errorf("summarizeDistribution count = (%v, %v), want (10, nil)", got, err)
+	}
+	if got, err := summarizeDistribution(d, "sum", 0); err != nil || got != 50 {
+		t.Errorf("summarizeDistribution sum = (%v, %v), want (50, nil)", got, err)
+	}
+	if _, err := summarizeDistribution(d, "bogus", 0); err == nil {
+		t.Error("summarizeDistribution with an unknown summary should return an error")
+	}
+}
+
+func TestDistributionPercentileLinearBuckets(t *testing.T) {
+	// Buckets: (-inf, 0], (0, 10], (10, 20]; counts 0, 2, 8 out of 10.
+	d := distributionWithLinearBuckets(0, 10, []int64{0, 2, 8}, 0, 10)
+
+	// The 50th percentile (5 of 10) falls in the third bucket, bound 20.
+	got, err := distributionPercentile(d, 50)
+	if err != nil {
+		t.Fatalf("distributionPercentile returned error: %v", err)
+	}
+	if got != 20 {
+		t.Errorf("distributionPercentile(50) = %v, want 20", got)
+	}
+
+	// The 10th percentile (1 of 10) falls in the second bucket, bound 10.
+	got, err = distributionPercentile(d, 10)
+	if err != nil {
+		t.Fatalf("distributionPercentile returned error: %v", err)
+	}
+	if got != 10 {
+		t.Errorf("distributionPercentile(10) = %v, want 10", got)
+	}
+}
+
+func TestDistributionPercentileNoBuckets(t *testing.T) {
+	d := &distributionpb.Distribution{Count: 0}
+	if _, err := distributionPercentile(d, 50); err == nil {
+		t.Error("distributionPercentile with no buckets should return an error")
+	}
+}
+
+func TestBucketUpperBoundsExplicit(t *testing.T) {
+	opts := &distributionpb.Distribution_BucketOptions{
+		Options: &distributionpb.Distribution_BucketOptions_ExplicitBuckets{
+			ExplicitBuckets: &distributionpb.Distribution_BucketOptions_Explicit{
+				Bounds: []float64{10, 20},
+			},
+		},
+	}
+
+	bounds, err := bucketUpperBounds(opts, 3)
+	if err != nil {
+		t.Fatalf("bucketUpperBounds returned error: %v", err)
+	}
+	want := []float64{10, 20, 20}
+	for i, b := range bounds {
+		if b != want[i] {
+			t.Errorf("bucketUpperBounds[%d] = %v, want %v", i, b, want[i])
+		}
+	}
+}
+
+func TestBucketUpperBoundsExponential(t *testing.T) {
+	opts := &distributionpb.Distribution_BucketOptions{
+		Options: &distributionpb.Distribution_BucketOptions_ExponentialBuckets{
+			ExponentialBuckets: &distributionpb.Distribution_BucketOptions_Exponential{
+				NumFiniteBuckets: 3,
+				GrowthFactor:     2,
+				Scale:            1,
+			},
+		},
+	}
+
+	bounds, err := bucketUpperBounds(opts, 3)
+	if err != nil {
+		t.Fatalf("bucketUpperBounds returned error: %v", err)
+	}
+	want := []float64{1, 2, 4}
+	for i, b := range bounds {
+		if b != want[i] {
+			t.Errorf("bucketUpperBounds[%d] = %v, want %v", i, b, want[i])
+		}
+	}
+}
+
+func TestBucketUpperBoundsNoOptions(t *testing.T) {
+	if _, err := bucketUpperBounds(&distributionpb.Distribution_BucketOptions{}, 2); err == nil {
+		t.Error("bucketUpperBounds with no options set should return an error")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// timedValue is one point's extracted value paired with the Unix
+// timestamp (seconds) it was reported at.
+type timedValue struct {
+	seconds float64
+	value   float64
+}
+
+// reduce collapses a series' points down to the single float64 a
+// threshold is checked against, per the named reducer. points must
+// already be sorted oldest-to-newest.
+func reduce(reducer string, points []timedValue) (float64, error) {
+	if len(points) == 0 {
+		return 0, fmt.Errorf("no points to reduce")
+	}
+
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.value
+	}
+
+	switch reducer {
+	case "", "last":
+		return values[len(values)-1], nil
+	case "min":
+		return minOf(values), nil
+	case "max":
+		return maxOf(values), nil
+	case "mean":
+		return mean(values), nil
+	case "stddev":
+		return stddev(values), nil
+	case "delta":
+		return values[len(values)-1] - values[0], nil
+	case "rate":
+		return rate(points)
+	default:
+		return 0, fmt.Errorf("unknown reducer %q", reducer)
+	}
+}
+
+// pointRange returns the minimum and maximum observed value across
+// points, for reporting alongside a reduced value in perfdata.
+func pointRange(points []timedValue) (min, max float64) {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.value
+	}
+	return minOf(values), maxOf(values)
+}
+
+func minOf(values []float64) float64 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func maxOf(values []float64) float64 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func stddev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+
+	m := mean(values)
+	var sumSq float64
+	for _, v := range values {
+		d := v - m
+		sumSq += d * d
+	}
+	return math.Sqrt(sumSq / float64(len(values)-1))
+}
+
+// rate returns the average rate of change per second between the first
+// and last point.
+func rate(points []timedValue) (float64, error) {
+	first, last := points[0], points[len(points)-1]
+	interval := last.seconds - first.seconds
+	if interval == 0 {
+		return 0, fmt.Errorf("cannot compute rate: points share the same timestamp")
+	}
+	return (last.value - first.value) / interval, nil
+}
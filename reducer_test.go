@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func points(values ...float64) []timedValue {
+	pts := make([]timedValue, len(values))
+	for i, v := range values {
+		pts[i] = timedValue{seconds: float64(i * 60), value: v}
+	}
+	return pts
+}
+
+func TestReduce(t *testing.T) {
+	cases := []struct {
+		reducer string
+		points  []timedValue
+		want    float64
+	}{
+		{"", points(1, 2, 3), 3},
+		{"last", points(1, 2, 3), 3},
+		{"min", points(3, 1, 2), 1},
+		{"max", points(3, 1, 2), 3},
+		{"mean", points(1, 2, 3), 2},
+		{"delta", points(1, 2, 5), 4},
+		{"rate", points(0, 60), 1},
+	}
+
+	for _, c := range cases {
+		got, err := reduce(c.reducer, c.points)
+		if err != nil {
+			t.Errorf("reduce(%q, ...) returned error: %v", c.reducer, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("reduce(%q, ...) = %v, want %v", c.reducer, got, c.want)
+		}
+	}
+}
+
+func TestReduceNoPoints(t *testing.T) {
+	if _, err := reduce("last", nil); err == nil {
+		t.Error("reduce with no points should return an error")
+	}
+}
+
+func TestReduceUnknownReducer(t *testing.T) {
+	if _, err := reduce("bogus", points(1)); err == nil {
+		t.Error("reduce with an unknown reducer should return an error")
+	}
+}
+
+func TestReduceRateSameTimestamp(t *testing.T) {
+	same := []timedValue{{seconds: 60, value: 1}, {seconds: 60, value: 2}}
+	if _, err := reduce("rate", same); err == nil {
+		t.Error("reduce rate over points sharing a timestamp should return an error")
+	}
+}
+
+func TestStddevSinglePoint(t *testing.T) {
+	if got := stddev([]float64{5}); got != 0 {
+		t.Errorf("stddev of a single value = %v, want 0", got)
+	}
+}
+
+func TestStddev(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	const want = 2.138089935299395 // sample stddev (n-1 denominator)
+	if got := stddev(values); math.Abs(got-want) > 1e-9 {
+		t.Errorf("stddev(%v) = %v, want %v", values, got, want)
+	}
+}
+
+func TestPointRange(t *testing.T) {
+	min, max := pointRange(points(3, 1, 2))
+	if min != 1 || max != 3 {
+		t.Errorf("pointRange = (%v, %v), want (1, 3)", min, max)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/olorin/nagiosplugin"
+)
+
+// trips reports whether value should alert according to comparison.
+// For comparison "range" (the default), threshold is parsed using
+// standard Nagios range syntax (10, 10:, ~:20, @10:20) and value trips
+// the alert when it falls outside the range (inside it, if the range is
+// inverted with a leading "@"). For the other comparisons, threshold is
+// a plain number compared against value with the named operator.
+func trips(comparison, threshold string, value float64) (bool, error) {
+	if threshold == "" {
+		return false, nil
+	}
+
+	if comparison == "" || comparison == "range" {
+		r, err := nagiosplugin.ParseRange(threshold)
+		if err != nil {
+			return false, fmt.Errorf("invalid threshold range %q: %v", threshold, err)
+		}
+		return r.Check(value), nil
+	}
+
+	t, err := strconv.ParseFloat(threshold, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid threshold %q: %v", threshold, err)
+	}
+
+	switch comparison {
+	case "gt":
+		return value > t, nil
+	case "ge":
+		return value >= t, nil
+	case "lt":
+		return value < t, nil
+	case "le":
+		return value <= t, nil
+	case "eq":
+		return value == t, nil
+	case "ne":
+		return value != t, nil
+	default:
+		return false, fmt.Errorf("unknown comparison %q", comparison)
+	}
+}
+
+// thresholdFloat extracts the single number nagiosplugin.AddPerfDatum's
+// warn/crit fields expect from threshold: the plain number itself for
+// non-range comparisons, or the finite edge of the parsed Nagios range
+// otherwise. It returns positive infinity, which AddPerfDatum omits from
+// its output, when threshold is empty or unparsable.
+func thresholdFloat(comparison, threshold string) float64 {
+	if threshold == "" {
+		return math.Inf(1)
+	}
+
+	if comparison == "" || comparison == "range" {
+		r, err := nagiosplugin.ParseRange(threshold)
+		if err != nil {
+			return math.Inf(1)
+		}
+		if !math.IsInf(r.End, 1) {
+			return r.End
+		}
+		if !math.IsInf(r.Start, -1) {
+			return r.Start
+		}
+		return math.Inf(1)
+	}
+
+	t, err := strconv.ParseFloat(threshold, 64)
+	if err != nil {
+		return math.Inf(1)
+	}
+	return t
+}
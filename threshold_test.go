@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTripsRange(t *testing.T) {
+	cases := []struct {
+		comparison string
+		threshold  string
+		value      float64
+		want       bool
+	}{
+		{"range", "10", 5, false},
+		{"range", "10", 15, true},
+		{"range", "10:", 5, true},
+		{"range", "10:", 15, false},
+		{"range", "~:20", -100, false},
+		{"range", "~:20", 25, true},
+		{"range", "@10:20", 15, true},
+		{"range", "@10:20", 25, false},
+		{"", "10", 15, true},
+	}
+
+	for _, c := range cases {
+		got, err := trips(c.comparison, c.threshold, c.value)
+		if err != nil {
+			t.Errorf("trips(%q, %q, %v) returned error: %v", c.comparison, c.threshold, c.value, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("trips(%q, %q, %v) = %v, want %v", c.comparison, c.threshold, c.value, got, c.want)
+		}
+	}
+}
+
+func TestTripsComparisons(t *testing.T) {
+	cases := []struct {
+		comparison string
+		threshold  string
+		value      float64
+		want       bool
+	}{
+		{"gt", "10", 11, true},
+		{"gt", "10", 10, false},
+		{"ge", "10", 10, true},
+		{"lt", "10", 9, true},
+		{"lt", "10", 10, false},
+		{"le", "10", 10, true},
+		{"eq", "10", 10, true},
+		{"eq", "10", 11, false},
+		{"ne", "10", 11, true},
+		{"ne", "10", 10, false},
+	}
+
+	for _, c := range cases {
+		got, err := trips(c.comparison, c.threshold, c.value)
+		if err != nil {
+			t.Errorf("trips(%q, %q, %v) returned error: %v", c.comparison, c.threshold, c.value, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("trips(%q, %q, %v) = %v, want %v", c.comparison, c.threshold, c.value, got, c.want)
+		}
+	}
+}
+
+func TestTripsEmptyThresholdNeverAlerts(t *testing.T) {
+	got, err := trips("gt", "", 1000)
+	if err != nil {
+		t.Fatalf("trips with an empty threshold returned error: %v", err)
+	}
+	if got {
+		t.Error("trips with an empty threshold should never alert")
+	}
+}
+
+func TestTripsInvalidInput(t *testing.T) {
+	if _, err := trips("range", "not-a-range", 1); err == nil {
+		t.Error("trips with an invalid range string should return an error")
+	}
+	if _, err := trips("gt", "not-a-number", 1); err == nil {
+		t.Error("trips with an invalid numeric threshold should return an error")
+	}
+	if _, err := trips("bogus", "10", 1); err == nil {
+		t.Error("trips with an unknown comparison should return an error")
+	}
+}
+
+func TestThresholdFloat(t *testing.T) {
+	cases := []struct {
+		comparison string
+		threshold  string
+		want       float64
+	}{
+		{"range", "10", 10},
+		{"range", "10:20", 20},
+		{"range", "~:20", 20},
+		{"range", "10:", 10},
+		{"gt", "10", 10},
+	}
+
+	for _, c := range cases {
+		got := thresholdFloat(c.comparison, c.threshold)
+		if got != c.want {
+			t.Errorf("thresholdFloat(%q, %q) = %v, want %v", c.comparison, c.threshold, got, c.want)
+		}
+	}
+
+	if got := thresholdFloat("range", ""); !math.IsInf(got, 1) {
+		t.Errorf("thresholdFloat with an empty threshold = %v, want +Inf", got)
+	}
+
+	if got := thresholdFloat("gt", "not-a-number"); !math.IsInf(got, 1) {
+		t.Errorf("thresholdFloat with an unparsable threshold = %v, want +Inf", got)
+	}
+}